@@ -0,0 +1,235 @@
+// Package api exposes the operations normally only reachable through the
+// interactive gocui UI (list environments, list/refresh nodes, start/stop
+// port-forwards, query forwarding health) over HTTP/JSON, so `tpot daemon`
+// can be driven from scripts, a browser UI, or the `tpot ctl` client.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/forward"
+	"github.com/adzimzf/tpot/scrapper"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/hashicorp/go-hclog"
+)
+
+// activeForward is what Server tracks per running forward: the node list
+// (for reporting Status/Error/ListenPort) and the Supervisor handle needed
+// to actually stop it.
+type activeForward struct {
+	list []*config.ForwardingNode
+	sup  *forward.Supervisor
+}
+
+// Server reuses config.Config/tsh.TSH/scrapper.Scrapper directly so the
+// daemon's behavior stays identical to the CLI's.
+type Server struct {
+	cfg    *config.Config
+	logger hclog.Logger
+
+	mu       sync.Mutex
+	forwards map[string]*activeForward
+}
+
+// NewServer builds a Server backed by cfg. logger is used for every request
+// and background forwarding goroutine it starts.
+func NewServer(cfg *config.Config, logger hclog.Logger) *Server {
+	return &Server{
+		cfg:      cfg,
+		logger:   logger,
+		forwards: make(map[string]*activeForward),
+	}
+}
+
+// Handler returns the http.Handler implementing the routes documented on
+// Server's methods.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/envs", s.handleEnvs)
+	mux.HandleFunc("/envs/", s.handleEnvNodes)
+	mux.HandleFunc("/forward", s.handleForward)
+	mux.HandleFunc("/forward/", s.handleForwardByID)
+	return mux
+}
+
+// GET /envs - lists the configured environment names.
+func (s *Server) handleEnvs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	envs := make([]string, 0, len(s.cfg.Proxies))
+	for _, p := range s.cfg.Proxies {
+		envs = append(envs, p.Env)
+	}
+	writeJSON(w, envs)
+}
+
+// GET /envs/{env}/nodes?refresh=1 - lists the nodes cached for env, or with
+// refresh=1 a fresh list fetched from the proxy; the fresh list is not
+// written back to the cache.
+func (s *Server) handleEnvNodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	env, ok := pathSegment(r.URL.Path, "/envs/", "/nodes")
+	if !ok {
+		http.Error(w, "expected /envs/{env}/nodes", http.StatusNotFound)
+		return
+	}
+
+	proxy, err := s.cfg.FindProxy(env)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	var nodes config.Node
+	if r.URL.Query().Get("refresh") == "1" {
+		nodes, err = s.refreshNodes(proxy)
+	} else {
+		nodes, err = proxy.GetNode()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, nodes)
+}
+
+func (s *Server) refreshNodes(proxy *config.Proxy) (config.Node, error) {
+	t := tsh.NewTSH(proxy, s.logger)
+	if proxy.AuthConnector == "" {
+		return scrapper.NewScrapper(*proxy, s.logger).GetNodes()
+	}
+	return t.ListNodes()
+}
+
+// forwardRequest is the body for POST /forward.
+type forwardRequest struct {
+	Env        string `json:"env"`
+	Host       string `json:"host"`
+	User       string `json:"user"`
+	ListenPort string `json:"listen_port"`
+}
+
+// GET /forward - returns the per-node Status/Error/ListenPort of every
+// active forward. POST /forward - starts a new forward from
+// config.Proxy.Forwarding for the given env.
+func (s *Server) handleForward(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		nodes := make([]*config.ForwardingNode, 0)
+		for _, f := range s.forwards {
+			nodes = append(nodes, f.list...)
+		}
+		writeJSON(w, nodes)
+	case http.MethodPost:
+		s.startForward(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) startForward(w http.ResponseWriter, r *http.Request) {
+	var req forwardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	proxy, err := s.cfg.FindProxy(req.Env)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	f := &forward.Forward{
+		TSH:         tsh.NewTSH(proxy, s.logger),
+		NodeHost:    req.Host,
+		List:        proxy.Forwarding.Nodes,
+		DefaultUser: req.User,
+		Logger:      s.logger,
+	}
+
+	sup, err := f.RunHeadless()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	if old, ok := s.forwards[req.Env]; ok {
+		old.sup.Shutdown()
+	}
+	s.forwards[req.Env] = &activeForward{list: f.List, sup: sup}
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// DELETE /forward/{id} - stops the forward started for env {id}.
+func (s *Server) handleForwardByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/forward/")
+	if id == "" {
+		http.Error(w, "expected /forward/{id}", http.StatusNotFound)
+		return
+	}
+
+	s.mu.Lock()
+	f, ok := s.forwards[id]
+	delete(s.forwards, id)
+	s.mu.Unlock()
+
+	if !ok {
+		http.Error(w, fmt.Sprintf("no forward running for %s", id), http.StatusNotFound)
+		return
+	}
+	f.sup.Shutdown()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pathSegment extracts {env} out of a path shaped like prefix+{env}+suffix.
+func pathSegment(path, prefix, suffix string) (string, bool) {
+	if !strings.HasPrefix(path, prefix) || !strings.HasSuffix(path, suffix) {
+		return "", false
+	}
+	seg := strings.TrimSuffix(strings.TrimPrefix(path, prefix), suffix)
+	if seg == "" {
+		return "", false
+	}
+	return seg, true
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// WithToken wraps next with bearer-token auth, for use when the daemon is
+// exposed over TCP instead of the default unix socket.
+func WithToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
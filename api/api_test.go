@@ -0,0 +1,113 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/forward"
+	"github.com/hashicorp/go-hclog"
+)
+
+func newTestServer(proxies ...config.Proxy) *Server {
+	return NewServer(&config.Config{Proxies: proxies}, hclog.NewNullLogger())
+}
+
+func TestHandleEnvs(t *testing.T) {
+	srv := newTestServer(config.Proxy{Env: "staging"}, config.Proxy{Env: "prod"})
+
+	req := httptest.NewRequest(http.MethodGet, "/envs", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "staging") || !strings.Contains(body, "prod") {
+		t.Errorf("body = %q, want it to contain both env names", body)
+	}
+}
+
+func TestHandleEnvsMethodNotAllowed(t *testing.T) {
+	srv := newTestServer()
+
+	req := httptest.NewRequest(http.MethodPost, "/envs", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+// TestStartForwardUnknownEnv makes sure POST /forward reports an unknown env
+// instead of silently accepting it, since startForward used to only be
+// exercised manually against a daemon with no way to assert this.
+func TestStartForwardUnknownEnv(t *testing.T) {
+	srv := newTestServer()
+
+	req := httptest.NewRequest(http.MethodPost, "/forward", strings.NewReader(`{"env":"nope"}`))
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+// TestStartForwardEmptyConfig covers the case RunHeadless rejects: a proxy
+// with no forwarding nodes configured should fail the request instead of
+// silently accepting it and doing nothing.
+func TestStartForwardEmptyConfig(t *testing.T) {
+	srv := newTestServer(config.Proxy{Env: "staging"})
+
+	req := httptest.NewRequest(http.MethodPost, "/forward", strings.NewReader(`{"env":"staging"}`))
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+// TestDeleteForwardUnregisters verifies DELETE /forward/{id} removes the
+// bookkeeping entry and stops tracking it, so a second DELETE correctly
+// reports 404 instead of a leak. The Supervisor it cancels has no nodes, so
+// Shutdown is a no-op here, but the route handling around it is what this
+// test is exercising.
+func TestDeleteForwardUnregisters(t *testing.T) {
+	srv := newTestServer()
+
+	sup := forward.NewSupervisor(nil, "", "", hclog.NewNullLogger())
+	srv.forwards["staging"] = &activeForward{sup: sup}
+
+	req := httptest.NewRequest(http.MethodDelete, "/forward/staging", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+
+	req2 := httptest.NewRequest(http.MethodDelete, "/forward/staging", nil)
+	w2 := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w2, req2)
+	if w2.Code != http.StatusNotFound {
+		t.Fatalf("second delete status = %d, want %d", w2.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandleForwardByIDMissingID(t *testing.T) {
+	srv := newTestServer()
+
+	req := httptest.NewRequest(http.MethodDelete, "/forward/", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
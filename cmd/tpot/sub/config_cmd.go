@@ -0,0 +1,160 @@
+package sub
+
+import (
+	"fmt"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+// ConfigCmd groups the `tpot config show|add|edit` subcommands that used to
+// be reached via `tpot -c [--add|--edit]`.
+var ConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show, add or edit the tpot configuration",
+}
+
+// ConfigShowCmd, ConfigAddCmd and ConfigEditCmd are exported so main.go's
+// deprecated flag-based entry points can forward straight into them instead
+// of reimplementing their bodies.
+var ConfigShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the configuration list",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+		str, err := cfg.String()
+		if err != nil {
+			return fmt.Errorf("failed to get config string, error:%v", err)
+		}
+		cmd.Println(str)
+		return nil
+	},
+}
+
+var ConfigAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a teleport configuration",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		res, err := cfg.Add()
+		if err != nil {
+			logger.Error("failed to add config", "error", err)
+		}
+
+		for res != "" && err != nil {
+			confirm, err := ui.Confirm("Do You want to continue edit", logger)
+			if err != nil {
+				logger.Error("failed to get confirmation", "error", err)
+				break
+			}
+			if !confirm {
+				break
+			}
+			res, err = cfg.AddPlain(res)
+			if err != nil {
+				logger.Error("failed to add config", "error", err)
+			}
+			if err == nil {
+				logger.Info("config added successfully")
+				break
+			}
+		}
+		return nil
+	},
+}
+
+var ConfigEditCmd = &cobra.Command{
+	Use:     "edit [env]",
+	Short:   "Edit all or one environment's configuration",
+	Example: "tpot config edit\ntpot config edit staging",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			return editAllConfig(cfg)
+		}
+
+		proxy, err := findProxy(cmd, cfg, args)
+		if err != nil {
+			return err
+		}
+		if err := editProxyConfig(cfg, proxy); err != nil {
+			return err
+		}
+		cmd.Printf("%s has updated successfully\n", proxy.Env)
+		return nil
+	},
+}
+
+func editAllConfig(c *config.Config) error {
+	res, err := c.EditAll()
+	if err != nil {
+		logger.Error("failed to edit config", "error", err)
+	}
+
+	for res != "" && err != nil {
+		confirm, err := ui.Confirm("Do You want to continue edit", logger)
+		if err != nil {
+			logger.Error("failed to get confirmation", "error", err)
+			break
+		}
+		if !confirm {
+			break
+		}
+		res, err = c.EditAllPlain(res)
+		if err != nil {
+			logger.Error("failed to edit config", "error", err)
+		}
+		if err == nil {
+			logger.Info("config edited successfully")
+			break
+		}
+	}
+	return nil
+}
+
+func editProxyConfig(c *config.Config, proxy *config.Proxy) error {
+	res, err := c.Edit(proxy.Env)
+	if err != nil {
+		logger.Error("failed to edit proxy", "env", proxy.Env, "error", err)
+	}
+
+	for res != "" && err != nil {
+		confirm, err := ui.Confirm("Do You want to continue edit", logger)
+		if err != nil {
+			logger.Error("failed to get confirmation", "error", err)
+			break
+		}
+		if !confirm {
+			break
+		}
+		res, err = c.EditPlain(proxy.Env, res)
+		if err != nil {
+			logger.Error("failed to edit proxy", "env", proxy.Env, "error", err)
+		}
+		if err == nil {
+			logger.Info("proxy edited successfully", "env", proxy.Env)
+			break
+		}
+	}
+	return nil
+}
+
+func init() {
+	addCommonFlags(ConfigCmd)
+	addCommonFlags(ConfigShowCmd)
+	addCommonFlags(ConfigAddCmd)
+	addCommonFlags(ConfigEditCmd)
+	ConfigCmd.AddCommand(ConfigShowCmd, ConfigAddCmd, ConfigEditCmd)
+}
@@ -0,0 +1,169 @@
+package sub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/spf13/cobra"
+)
+
+// CtlCmd groups the `tpot ctl` client subcommands that talk to a running
+// `tpot daemon`, giving a remote box the same UX as the interactive CLI.
+var CtlCmd = &cobra.Command{
+	Use:   "ctl",
+	Short: "Talk to a running tpot daemon",
+}
+
+var ctlEnvsCmd = &cobra.Command{
+	Use:   "envs",
+	Short: "List the daemon's configured environments",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		body, err := ctlRequest(cmd, http.MethodGet, "/envs", nil)
+		if err != nil {
+			return err
+		}
+		cmd.Println(string(body))
+		return nil
+	},
+}
+
+var ctlNodesCmd = &cobra.Command{
+	Use:     "nodes <env>",
+	Short:   "List the nodes cached for an environment",
+	Example: "tpot ctl nodes prod\ntpot ctl nodes prod --refresh",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return fmt.Errorf("environment argument is required")
+		}
+		path := fmt.Sprintf("/envs/%s/nodes", args[0])
+		if refresh, _ := cmd.Flags().GetBool("refresh"); refresh {
+			path += "?refresh=1"
+		}
+		body, err := ctlRequest(cmd, http.MethodGet, path, nil)
+		if err != nil {
+			return err
+		}
+		cmd.Println(string(body))
+		return nil
+	},
+}
+
+var ctlForwardCmd = &cobra.Command{
+	Use:     "forward <env>",
+	Short:   "Start a port-forward through the daemon",
+	Example: "tpot ctl forward prod --host node1 --user root --listen-port 2222",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return fmt.Errorf("environment argument is required")
+		}
+		host, _ := cmd.Flags().GetString("host")
+		user, _ := cmd.Flags().GetString("user")
+		listenPort, _ := cmd.Flags().GetString("listen-port")
+
+		reqBody, err := json.Marshal(map[string]string{
+			"env":         args[0],
+			"host":        host,
+			"user":        user,
+			"listen_port": listenPort,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = ctlRequest(cmd, http.MethodPost, "/forward", bytes.NewReader(reqBody))
+		return err
+	},
+}
+
+var ctlUnforwardCmd = &cobra.Command{
+	Use:     "unforward <env>",
+	Short:   "Stop a port-forward started through the daemon",
+	Example: "tpot ctl unforward prod",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return fmt.Errorf("environment argument is required")
+		}
+		_, err := ctlRequest(cmd, http.MethodDelete, "/forward/"+args[0], nil)
+		return err
+	},
+}
+
+// ctlRequest performs an HTTP request against the daemon, dialing the unix
+// socket by default or the TCP --addr/--token when set.
+func ctlRequest(cmd *cobra.Command, method, path string, body io.Reader) ([]byte, error) {
+	addr, err := cmd.Flags().GetString("addr")
+	if err != nil {
+		return nil, err
+	}
+	token, err := cmd.Flags().GetString("token")
+	if err != nil {
+		return nil, err
+	}
+	socket, err := cmd.Flags().GetString("socket")
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{}
+	url := "http://daemon" + path
+	if addr == "" {
+		if socket == "" {
+			socket = filepath.Join(config.Dir, "tpot.sock")
+		}
+		client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return net.Dial("unix", socket)
+			},
+		}
+	} else {
+		url = "http://" + addr + path
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach tpot daemon: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("daemon returned %s: %s", resp.Status, respBody)
+	}
+	return respBody, nil
+}
+
+func addCtlFlags(cmd *cobra.Command) {
+	cmd.Flags().String("socket", "", "unix socket path (default <config dir>/tpot.sock)")
+	cmd.Flags().String("addr", "", "daemon TCP address, instead of the unix socket")
+	cmd.Flags().String("token", "", "bearer token for a TCP daemon")
+}
+
+func init() {
+	addCommonFlags(CtlCmd)
+	for _, c := range []*cobra.Command{ctlEnvsCmd, ctlNodesCmd, ctlForwardCmd, ctlUnforwardCmd} {
+		addCommonFlags(c)
+		addCtlFlags(c)
+	}
+	ctlNodesCmd.Flags().BoolP("refresh", "r", false, "refresh the node cache before listing")
+	ctlForwardCmd.Flags().String("host", "", "host to forward to")
+	ctlForwardCmd.Flags().StringP("user", "u", "", "user to login to the desired host")
+	ctlForwardCmd.Flags().String("listen-port", "", "sent to the daemon as listen_port; the daemon does not use it and listens on the ports from the env's forwarding config")
+	CtlCmd.AddCommand(ctlEnvsCmd, ctlNodesCmd, ctlForwardCmd, ctlUnforwardCmd)
+}
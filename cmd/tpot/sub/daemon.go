@@ -0,0 +1,76 @@
+package sub
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/adzimzf/tpot/api"
+	"github.com/adzimzf/tpot/config"
+	"github.com/spf13/cobra"
+)
+
+// DaemonCmd implements `tpot daemon`, a small HTTP/JSON control server that
+// lets a headless machine drive tpot from scripts or a browser UI. It binds
+// a unix socket by default, or a TCP address with token auth when --addr is
+// set.
+var DaemonCmd = &cobra.Command{
+	Use:     "daemon",
+	Short:   "Run the tpot HTTP/JSON control API",
+	Example: "tpot daemon\ntpot daemon --addr 127.0.0.1:8585 --token s3cr3t",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		addr, err := cmd.Flags().GetString("addr")
+		if err != nil {
+			return err
+		}
+		token, err := cmd.Flags().GetString("token")
+		if err != nil {
+			return err
+		}
+		socket, err := cmd.Flags().GetString("socket")
+		if err != nil {
+			return err
+		}
+
+		srv := api.NewServer(cfg, logger)
+		handler := srv.Handler()
+
+		if addr != "" {
+			if token == "" {
+				return fmt.Errorf("--token is required when --addr is set")
+			}
+			handler = api.WithToken(token, handler)
+			logger.Info("tpot daemon listening on tcp", "addr", addr)
+			return http.ListenAndServe(addr, handler)
+		}
+
+		if socket == "" {
+			socket = filepath.Join(config.Dir, "tpot.sock")
+		}
+		if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to clear stale socket %s: %v", socket, err)
+		}
+		ln, err := net.Listen("unix", socket)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %v", socket, err)
+		}
+		defer ln.Close()
+
+		logger.Info("tpot daemon listening on unix socket", "socket", socket)
+		return http.Serve(ln, handler)
+	},
+}
+
+func init() {
+	addCommonFlags(DaemonCmd)
+	DaemonCmd.Flags().String("socket", "", "unix socket path (default "+"<config dir>/tpot.sock"+")")
+	DaemonCmd.Flags().String("addr", "", "listen on this TCP address instead of a unix socket")
+	DaemonCmd.Flags().String("token", "", "bearer token required when serving over TCP")
+}
@@ -0,0 +1,63 @@
+package sub
+
+import (
+	"fmt"
+
+	"github.com/adzimzf/tpot/forward"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+// ForwardCmd implements `tpot forward <env>`, the port-forwarding flow
+// previously reached via the `-L` flag.
+var ForwardCmd = &cobra.Command{
+	Use:     "forward <env>",
+	Short:   "Run tsh port forwarding based on the config list",
+	Example: "tpot forward prod",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		proxy, err := findProxy(cmd, cfg, args)
+		if err != nil {
+			return err
+		}
+
+		node, err := loadNode(cmd, proxy)
+		if err != nil {
+			return err
+		}
+		proxy.Node = *node
+
+		// TODO(chunk0-5): this picker still doesn't fuzzy-filter like
+		// ui.LoginUser does; ui.GetSelectedHost's implementation isn't part
+		// of this tree to extend.
+		host := ui.GetSelectedHost(proxy.Node.ListHostname(), logger)
+		if host == "" {
+			return fmt.Errorf("pick at least one host to login")
+		}
+
+		user, err := loginUser(cmd, &proxy.Node)
+		if err != nil {
+			return err
+		}
+
+		f := forward.Forward{
+			TSH:         tsh.NewTSH(proxy, logger),
+			List:        proxy.Forwarding.Nodes,
+			NodeHost:    host,
+			DefaultUser: user,
+			Logger:      logger,
+		}
+		return f.Run()
+	},
+}
+
+func init() {
+	addCommonFlags(ForwardCmd)
+	addNodeFlags(ForwardCmd)
+	ForwardCmd.Flags().StringP("user", "u", "", "user to login to the desired host")
+}
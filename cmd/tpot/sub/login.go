@@ -0,0 +1,86 @@
+package sub
+
+import (
+	"fmt"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/spf13/cobra"
+)
+
+// LoginCmd implements `tpot login <env>`, the direct SSH login flow that used
+// to be the root command's default action.
+var LoginCmd = &cobra.Command{
+	Use:     "login <env>",
+	Short:   "Login to a host in the given environment",
+	Example: "tpot login prod\ntpot login prod -u root",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		proxy, err := findProxy(cmd, cfg, args)
+		if err != nil {
+			return err
+		}
+
+		node, err := loadNode(cmd, proxy)
+		if err != nil {
+			return err
+		}
+		proxy.Node = *node
+
+		// TODO(chunk0-5): this picker still doesn't fuzzy-filter like
+		// ui.LoginUser does; ui.GetSelectedHost's implementation isn't part
+		// of this tree to extend.
+		host := ui.GetSelectedHost(node.ListHostname(), logger)
+		if host == "" {
+			return fmt.Errorf("pick at least one host to login")
+		}
+
+		user, err := loginUser(cmd, node)
+		if err != nil {
+			return err
+		}
+
+		cmd.Printf("login using %s %s\n", user, host)
+		return tsh.NewTSH(proxy, logger).SSH(user, host)
+	},
+}
+
+func init() {
+	addCommonFlags(LoginCmd)
+	addNodeFlags(LoginCmd)
+	LoginCmd.Flags().StringP("user", "u", "", "user to login to the desired host")
+}
+
+// loginUser resolves the user login either from the --user flag or, if
+// unset, by prompting the user through the ui.LoginUser selector.
+func loginUser(cmd *cobra.Command, node *config.Node) (string, error) {
+	userLogin, err := cmd.Flags().GetString("user")
+	if err != nil {
+		return "", err
+	}
+	if userLogin != "" {
+		return userLogin, nil
+	}
+
+	if node.Status == nil {
+		return "", fmt.Errorf("need to run using flag --refresh or --append to get the latest user login")
+	}
+
+	uiUser, err := ui.NewLoginUser(node.Status.UserLogins, logger)
+	if err != nil {
+		return "", err
+	}
+	user, err := uiUser.Run()
+	if err != nil {
+		return "", err
+	}
+	if user == "" {
+		return "", fmt.Errorf("user login must not be empty")
+	}
+	return user, nil
+}
@@ -0,0 +1,39 @@
+package sub
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// LsCmd implements `tpot ls <env>`, printing the node list of an
+// environment without going through the interactive host/login prompts.
+var LsCmd = &cobra.Command{
+	Use:     "ls <env>",
+	Short:   "Show the node list of an environment",
+	Example: "tpot ls staging\ntpot ls prod --refresh",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		proxy, err := findProxy(cmd, cfg, args)
+		if err != nil {
+			return err
+		}
+
+		node, err := loadNode(cmd, proxy)
+		if err != nil {
+			return err
+		}
+
+		cmd.Println(strings.Join(node.ListHostname(), "\n"))
+		return nil
+	},
+}
+
+func init() {
+	addCommonFlags(LsCmd)
+	addNodeFlags(LsCmd)
+}
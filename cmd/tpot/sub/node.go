@@ -0,0 +1,59 @@
+package sub
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// NodeCmd groups the `tpot node refresh|append` subcommands that drive the
+// node cache independently from logging in or forwarding.
+var NodeCmd = &cobra.Command{
+	Use:   "node",
+	Short: "Refresh or append the cached node list for an environment",
+}
+
+var nodeRefreshCmd = &cobra.Command{
+	Use:     "refresh <env>",
+	Short:   "Replace the node list from the proxy",
+	Example: "tpot node refresh prod",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runNodeFetch(cmd, args, false)
+	},
+}
+
+var nodeAppendCmd = &cobra.Command{
+	Use:     "append <env>",
+	Short:   "Append the fresh node list to the cache",
+	Example: "tpot node append prod",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runNodeFetch(cmd, args, true)
+	},
+}
+
+func runNodeFetch(cmd *cobra.Command, args []string, isAppend bool) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	proxy, err := findProxy(cmd, cfg, args)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := fetchLatestNode(proxy, isAppend)
+	if err != nil {
+		return err
+	}
+
+	cmd.Println(strings.Join(nodes.ListHostname(), "\n"))
+	return nil
+}
+
+func init() {
+	addCommonFlags(NodeCmd)
+	addCommonFlags(nodeRefreshCmd)
+	addCommonFlags(nodeAppendCmd)
+	NodeCmd.AddCommand(nodeRefreshCmd, nodeAppendCmd)
+}
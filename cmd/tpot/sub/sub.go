@@ -0,0 +1,157 @@
+// Package sub holds the individual tpot cobra subcommands (login, forward,
+// ls, config, node, daemon, ctl), one file per command, mirroring the layout
+// used by frp's cmd/frpc sub-package. Each subcommand owns its own flags,
+// config loading (loadConfig, called from its RunE) and error handling
+// instead of leaning on the root command's flag introspection.
+package sub
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/logging"
+	"github.com/adzimzf/tpot/scrapper"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spf13/cobra"
+)
+
+// logger is built once per process by loadConfig from --log-level/--log-format
+// and reused by every helper in this package.
+var logger hclog.Logger
+
+// loadConfig builds the shared logger and reads the tpot config using the
+// --developer/--log-level/--log-format flags every subcommand registers via
+// addCommonFlags.
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	isDev, err := cmd.Flags().GetBool("developer")
+	if err != nil {
+		return nil, err
+	}
+	level, err := cmd.Flags().GetString("log-level")
+	if err != nil {
+		return nil, err
+	}
+	format, err := cmd.Flags().GetString("log-format")
+	if err != nil {
+		return nil, err
+	}
+	logger = logging.New(level, format)
+
+	cfg, err := config.NewConfig(isDev, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config, error: %v", err)
+	}
+	return cfg, nil
+}
+
+// findProxy resolves the environment positional argument into a config.Proxy,
+// printing the command's help when it is missing or unknown.
+func findProxy(cmd *cobra.Command, cfg *config.Config, args []string) (*config.Proxy, error) {
+	if len(args) < 1 {
+		cmd.Help()
+		return nil, fmt.Errorf("environment argument is required")
+	}
+
+	proxy, err := cfg.FindProxy(args[0])
+	if errors.Is(err, config.ErrEnvNotFound) {
+		cmd.PrintErrf("Env %s not found\n\n", args[0])
+		cmd.Help()
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return proxy, nil
+}
+
+// loadNode fetches the node list for proxy honouring the subcommand's own
+// --refresh/--append flags.
+func loadNode(cmd *cobra.Command, proxy *config.Proxy) (*config.Node, error) {
+	isRefresh, err := cmd.Flags().GetBool("refresh")
+	if err != nil {
+		return nil, err
+	}
+	isAppend, err := cmd.Flags().GetBool("append")
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes config.Node
+	if isRefresh || isAppend {
+		nodes, err = fetchLatestNode(proxy, isAppend)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		nodes, err = proxy.GetNode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load nodes %v,\nyou might need --refresh to refresh the node cache", err)
+		}
+	}
+	return &nodes, nil
+}
+
+// fetchLatestNode pulls a fresh node list from the proxy (via tsh or the
+// scrapper fallback) and writes it to the on-disk cache, merged with the
+// cached list first when isAppend is set.
+func fetchLatestNode(proxy *config.Proxy, isAppend bool) (config.Node, error) {
+	var nodes config.Node
+	var err error
+	t := tsh.NewTSH(proxy, logger)
+	if proxy.AuthConnector == "" {
+		nodes, err = scrapper.NewScrapper(*proxy, logger).GetNodes()
+		if err != nil {
+			return nodes, fmt.Errorf("failed to get nodes: %v", err)
+		}
+	} else {
+		nodes, err = t.ListNodes()
+		if err != nil {
+			return nodes, fmt.Errorf("failed to get nodes: %v", err)
+		}
+	}
+
+	if len(nodes.Items) == 0 {
+		return nodes, fmt.Errorf("there's no nodes found")
+	}
+
+	if isAppend {
+		nodes, err = proxy.AppendNode(nodes)
+		if err != nil {
+			return nodes, fmt.Errorf("failed to append nodes, err: %v", err)
+		}
+	}
+
+	status, err := t.Status()
+	if err != nil && err != tsh.ErrUnsupportedVersion {
+		return nodes, err
+	}
+	if err == tsh.ErrUnsupportedVersion {
+		version, err := t.Version()
+		if err != nil {
+			return config.Node{}, err
+		}
+		logger.Warn("minimum tsh version is Teleport v2.6.1", "got", version.Strings(), "env", proxy.Env)
+		status = &config.ProxyStatus{UserLogins: []string{"root"}}
+	}
+
+	nodes.Status = status
+	go proxy.UpdateNode(nodes)
+	return nodes, nil
+}
+
+// addCommonFlags registers the --developer and logging flags shared by
+// every subcommand.
+func addCommonFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("developer", "D", false, "used only for developing this application")
+	cmd.Flags().String("log-level", "info", "log level: trace|debug|info|warn|error")
+	cmd.Flags().String("log-format", "text", "log format: text|json")
+}
+
+// addNodeFlags registers the --refresh/--append flags used by subcommands
+// that need the latest node list.
+func addNodeFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("refresh", "r", false, "Replace the node list from proxy")
+	cmd.Flags().BoolP("append", "a", false, "Append the fresh node list to the cache")
+}
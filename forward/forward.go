@@ -0,0 +1,258 @@
+// Package forward runs and supervises the tsh port-forwarding sessions
+// backing `tpot -L` / `tpot forward`. It is shared by the legacy root
+// command, the `forward` subcommand and the daemon's `POST /forward` route
+// so all three get identical behaviour.
+package forward
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/adzimzf/tpot/config"
+	"github.com/adzimzf/tpot/tsh"
+	"github.com/adzimzf/tpot/ui"
+	"github.com/hashicorp/go-hclog"
+)
+
+const (
+	minBackoff   = 100 * time.Millisecond
+	maxBackoff   = 30 * time.Second
+	stableUptime = time.Minute
+	probeEvery   = 2 * time.Second
+)
+
+// Forward owns the list of forwarding nodes for a single environment and
+// keeps them alive for as long as the process runs.
+type Forward struct {
+	TSH         *tsh.TSH
+	NodeHost    string
+	List        []*config.ForwardingNode
+	DefaultUser string
+	Logger      hclog.Logger
+}
+
+// logger falls back to a discarding logger when the caller didn't set one,
+// so the zero-value Forward stays usable.
+func (f *Forward) logger() hclog.Logger {
+	if f.Logger != nil {
+		return f.Logger
+	}
+	return hclog.NewNullLogger()
+}
+
+// Run hands every node to a Supervisor and blocks on the interactive
+// forwarding status UI until the user quits, at which point all forwarding
+// goroutines are torn down. Not for use in a headless process: the UI
+// needs a controlling TTY, and as soon as it returns the supervisor it
+// started is shut down. Use RunHeadless instead for that case.
+func (f *Forward) Run() error {
+	sup, err := f.RunHeadless()
+	if err != nil {
+		return err
+	}
+	defer sup.Shutdown()
+
+	ui.NewForwarding(f.List)
+	return nil
+}
+
+// RunHeadless starts a Supervisor over every node and returns it without
+// blocking on any UI, so a caller like the daemon can keep forwarding
+// alive across requests and later call Supervisor.Shutdown to stop it.
+func (f *Forward) RunHeadless() (*Supervisor, error) {
+	if len(f.List) == 0 {
+		return nil, fmt.Errorf("forwarding configuration is empty")
+	}
+
+	sup := NewSupervisor(f.TSH, f.NodeHost, f.DefaultUser, f.logger())
+	sup.Supervise(f.List)
+	return sup, nil
+}
+
+// forwardState is the supervisor's per-node state machine: cancel tears
+// down both its goroutines, restartCh is the event channel a liveness
+// probe pushes onto instead of calling back into the forwarder directly,
+// and backoff/stableSince implement the 100ms->30s exponential backoff
+// that resets after a minute of stable uptime.
+type forwardState struct {
+	cancel      context.CancelFunc
+	restartCh   chan struct{}
+	backoff     time.Duration
+	stableSince time.Time
+}
+
+// Supervisor owns the single per-node goroutine allowed to transition a
+// node's status and (re)invoke tsh.Forward. The periodic dial in probe is
+// a liveness check only: it signals restartCh, it never calls tsh.Forward
+// itself, so there is no longer a race between a health-check restart and
+// the reconnect loop already running for that node.
+type Supervisor struct {
+	tsh         *tsh.TSH
+	nodeHost    string
+	defaultUser string
+	logger      hclog.Logger
+
+	mu     sync.Mutex
+	states map[*config.ForwardingNode]*forwardState
+}
+
+// NewSupervisor creates a Supervisor that forwards through t to nodeHost,
+// defaulting to defaultUser when a node doesn't set its own UserLogin.
+func NewSupervisor(t *tsh.TSH, nodeHost, defaultUser string, logger hclog.Logger) *Supervisor {
+	return &Supervisor{
+		tsh:         t,
+		nodeHost:    nodeHost,
+		defaultUser: defaultUser,
+		logger:      logger,
+		states:      make(map[*config.ForwardingNode]*forwardState),
+	}
+}
+
+// Supervise starts a forwarding goroutine and a liveness probe for every
+// node in list.
+func (s *Supervisor) Supervise(list []*config.ForwardingNode) {
+	for _, node := range list {
+		s.start(node)
+	}
+}
+
+// Shutdown cancels every node's forwarding and probe goroutines so the tsh
+// child processes are torn down immediately instead of waiting out their
+// reader timeout.
+func (s *Supervisor) Shutdown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, state := range s.states {
+		state.cancel()
+	}
+}
+
+// Nodes returns the forwarding nodes this Supervisor is managing, so a
+// caller like the daemon can report their Status/Error/ListenPort without
+// reaching into the unexported state map.
+func (s *Supervisor) Nodes() []*config.ForwardingNode {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	nodes := make([]*config.ForwardingNode, 0, len(s.states))
+	for node := range s.states {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func (s *Supervisor) start(node *config.ForwardingNode) {
+	ctx, cancel := context.WithCancel(context.Background())
+	state := &forwardState{
+		cancel:    cancel,
+		restartCh: make(chan struct{}, 1),
+		backoff:   minBackoff,
+	}
+
+	s.mu.Lock()
+	s.states[node] = state
+	s.mu.Unlock()
+
+	go s.run(ctx, node, state)
+	go s.probe(ctx, node, state)
+}
+
+// run is the only goroutine allowed to call tsh.Forward or flip node's
+// status for as long as ctx is alive.
+func (s *Supervisor) run(ctx context.Context, node *config.ForwardingNode, state *forwardState) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if node.UserLogin == "" {
+			node.UserLogin = s.defaultUser
+		}
+		if node.Host == "" {
+			node.Host = s.nodeHost
+		}
+
+		s.logger.Info("starting forwarding", "user", node.UserLogin, "host", node.Host, "listen_port", node.ListenPort)
+		state.stableSince = time.Now()
+
+		in := &ctxReader{ctx: ctx, dur: stableUptime * 3}
+		err := s.tsh.Forward(node.UserLogin, s.nodeHost, node.Address(), in)
+		if ctx.Err() != nil {
+			return
+		}
+
+		switch err {
+		case nil, io.EOF:
+			node.Status = true
+			node.Error = ""
+		default:
+			node.Status = false
+			node.Error = err.Error()
+			s.logger.Error("forwarding failed", "user", node.UserLogin, "host", node.Host, "listen_port", node.ListenPort, "error", err)
+		}
+
+		if time.Since(state.stableSince) >= stableUptime {
+			state.backoff = minBackoff
+		} else {
+			state.backoff *= 2
+			if state.backoff > maxBackoff {
+				state.backoff = maxBackoff
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-state.restartCh:
+		case <-time.After(state.backoff):
+		}
+	}
+}
+
+// probe is a liveness check only: node.Status/node.Error belong to run
+// alone, so probe never writes them — it only nudges restartCh and lets
+// run observe and report the resulting reconnect.
+func (s *Supervisor) probe(ctx context.Context, node *config.ForwardingNode, state *forwardState) {
+	ticker := time.NewTicker(probeEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			conn, err := net.DialTimeout("tcp", net.JoinHostPort("localhost", node.ListenPort), time.Second)
+			if err != nil {
+				select {
+				case state.restartCh <- struct{}{}:
+				default:
+				}
+				continue
+			}
+			conn.Close()
+		}
+	}
+}
+
+// ctxReader blocks for dur and then returns io.EOF, but also unblocks
+// as soon as ctx is cancelled so Shutdown doesn't have to wait out the full
+// duration to tear down the tsh child.
+type ctxReader struct {
+	ctx context.Context
+	dur time.Duration
+}
+
+func (r *ctxReader) Read(p []byte) (n int, err error) {
+	timer := time.NewTimer(r.dur)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return 0, io.EOF
+	case <-r.ctx.Done():
+		return 0, r.ctx.Err()
+	}
+}
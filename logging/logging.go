@@ -0,0 +1,21 @@
+// Package logging builds the hclog.Logger shared by every other tpot
+// package (config, tsh, scrapper, forward, ui) so that --log-level and
+// --log-format consistently control all of tpot's output.
+package logging
+
+import (
+	"os"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// New builds the root logger from the --log-level/--log-format flag
+// values. An unrecognised level falls back to hclog.Info.
+func New(level, format string) hclog.Logger {
+	return hclog.New(&hclog.LoggerOptions{
+		Name:       "tpot",
+		Level:      hclog.LevelFromString(level),
+		Output:     os.Stderr,
+		JSONFormat: format == "json",
+	})
+}
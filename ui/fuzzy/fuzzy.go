@@ -0,0 +1,100 @@
+// Package fuzzy implements a small fzf v1-style fuzzy subsequence matcher,
+// used by ui.LoginUser to filter its list. The host selector
+// (ui.GetSelectedHost) does not use it and does not filter.
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	bonusBoundary  = 16 // match sits right after a word boundary ('_', '-', '/', '.', space)
+	bonusCamel     = 4  // match sits at a camelCase hump
+	gapPenalty     = -3 // penalty per skipped rune between two matched runes
+	firstCharBonus = 16 // match is the very first rune of the candidate
+)
+
+// Match scores query as a fuzzy subsequence of target. ok is false when
+// query isn't a subsequence of target at all.
+func Match(query, target string) (score int, ok bool) {
+	if query == "" {
+		return 0, true
+	}
+
+	q := []rune(strings.ToLower(query))
+	t := []rune(target)
+	tLower := []rune(strings.ToLower(target))
+
+	qi := 0
+	lastMatch := -1
+	for ti := 0; qi < len(q) && ti < len(tLower); ti++ {
+		if tLower[ti] != q[qi] {
+			continue
+		}
+
+		if ti == 0 {
+			score += firstCharBonus
+		} else if isBoundary(t[ti-1]) {
+			score += bonusBoundary
+		} else if isCamelBoundary(t[ti-1], t[ti]) {
+			score += bonusCamel
+		}
+
+		if lastMatch >= 0 {
+			score += gapPenalty * (ti - lastMatch - 1)
+		}
+		lastMatch = ti
+		qi++
+	}
+
+	if qi != len(q) {
+		return 0, false
+	}
+	return score, true
+}
+
+func isBoundary(r rune) bool {
+	switch r {
+	case '_', '-', '/', '.', ' ':
+		return true
+	}
+	return false
+}
+
+func isCamelBoundary(prev, cur rune) bool {
+	isLower := func(r rune) bool { return r >= 'a' && r <= 'z' }
+	isUpper := func(r rune) bool { return r >= 'A' && r <= 'Z' }
+	return isLower(prev) && isUpper(cur)
+}
+
+// result pairs a candidate's original index with its match score, so
+// Filter can sort by score while keeping a stable tie-break on the
+// original order.
+type result struct {
+	index int
+	score int
+}
+
+// Filter returns the indices of candidates that fuzzy-match query, best
+// match first. An empty query returns every index, unfiltered.
+func Filter(query string, candidates []string) []int {
+	results := make([]result, 0, len(candidates))
+	for i, c := range candidates {
+		score, ok := Match(query, c)
+		if !ok {
+			continue
+		}
+		results = append(results, result{index: i, score: score})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score > results[j].score
+	})
+
+	indices := make([]int, len(results))
+	for i, r := range results {
+		indices[i] = r.index
+	}
+	return indices
+}
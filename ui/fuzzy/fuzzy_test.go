@@ -0,0 +1,100 @@
+package fuzzy
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		target string
+		ok     bool
+	}{
+		{"empty query always matches", "", "anything", true},
+		{"exact match", "abc", "abc", true},
+		{"subsequence out of order does not match", "ba", "abc", false},
+		{"case insensitive", "ABC", "abc", true},
+		{"not a subsequence", "xyz", "abc", false},
+		{"subsequence with gaps", "ac", "abc", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := Match(tt.query, tt.target)
+			if ok != tt.ok {
+				t.Errorf("Match(%q, %q) ok = %v, want %v", tt.query, tt.target, ok, tt.ok)
+			}
+		})
+	}
+}
+
+// TestMatchScoring pins down the bonus/penalty scheme: a first-rune,
+// word-boundary or camelCase match scores exactly its bonus, and the same
+// letters matched with a skipped gap in between score lower than without it.
+func TestMatchScoring(t *testing.T) {
+	firstChar, ok := Match("a", "abc")
+	if !ok {
+		t.Fatal("expected match")
+	}
+	if firstChar != firstCharBonus {
+		t.Errorf("first-char match score = %d, want %d", firstChar, firstCharBonus)
+	}
+
+	boundary, ok := Match("b", "a_b")
+	if !ok {
+		t.Fatal("expected match")
+	}
+	if boundary != bonusBoundary {
+		t.Errorf("boundary match score = %d, want %d", boundary, bonusBoundary)
+	}
+
+	camel, ok := Match("b", "aBc")
+	if !ok {
+		t.Fatal("expected match")
+	}
+	if camel != bonusCamel {
+		t.Errorf("camel match score = %d, want %d", camel, bonusCamel)
+	}
+
+	gapped, ok := Match("ac", "azc")
+	if !ok {
+		t.Fatal("expected match")
+	}
+	noGap, ok := Match("ac", "ac")
+	if !ok {
+		t.Fatal("expected match")
+	}
+	if gapped >= noGap {
+		t.Errorf("gapped score %d should be lower than ungapped score %d", gapped, noGap)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	candidates := []string{"prod-web", "staging-web", "prod-db"}
+
+	t.Run("empty query returns every index unfiltered", func(t *testing.T) {
+		got := Filter("", candidates)
+		if len(got) != len(candidates) {
+			t.Fatalf("got %d indices, want %d", len(got), len(candidates))
+		}
+	})
+
+	t.Run("equal scores keep input order", func(t *testing.T) {
+		got := Filter("prod", candidates)
+		want := []int{0, 2}
+		if len(got) != len(want) {
+			t.Fatalf("got %v, want indices %v", got, want)
+		}
+		for i, idx := range want {
+			if got[i] != idx {
+				t.Errorf("got[%d] = %d, want %d", i, got[i], idx)
+			}
+		}
+	})
+
+	t.Run("no match returns empty", func(t *testing.T) {
+		got := Filter("zzz", candidates)
+		if len(got) != 0 {
+			t.Errorf("got %v, want no matches", got)
+		}
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/adzimzf/tpot/ui/fuzzy"
+	"github.com/hashicorp/go-hclog"
 	"github.com/jroimartin/gocui"
 )
 
@@ -13,16 +15,24 @@ type LoginUser struct {
 	width, height int
 	g             *gocui.Gui
 	viewName      string
+	logger        hclog.Logger
 
 	// selectedUser is the selected user when click Enter
 	selectedUser string
 
-	// pos indicates the current arrow position
+	// query is the current fuzzy-filter text typed by the user
+	query string
+
+	// visible holds the indices into list that match query, best match
+	// first; pos indexes into visible, not list
+	visible []int
+
+	// pos indicates the current arrow position within visible
 	pos int
 }
 
 // NewLoginUser create a new login user UI
-func NewLoginUser(listUser []string) (*LoginUser, error) {
+func NewLoginUser(listUser []string, logger hclog.Logger) (*LoginUser, error) {
 	g, err := gocui.NewGui(gocui.OutputNormal)
 	if err != nil {
 		return nil, err
@@ -36,7 +46,9 @@ func NewLoginUser(listUser []string) (*LoginUser, error) {
 		list:     listUser,
 		g:        g,
 		viewName: "login_user_selector",
+		logger:   logger,
 	}
+	l.recompute()
 	l.g.SetManagerFunc(func(gui *gocui.Gui) error {
 		return l.registerView()
 	})
@@ -48,6 +60,13 @@ func NewLoginUser(listUser []string) (*LoginUser, error) {
 	return l, nil
 }
 
+// recompute refilters list against query, moving the cursor back to the
+// best match since a query edit can reorder visible entirely.
+func (l *LoginUser) recompute() {
+	l.visible = fuzzy.Filter(l.query, l.list)
+	l.pos = 0
+}
+
 // getY return the initial start & end Y
 func (l *LoginUser) getY() (yStart int, yEnd int) {
 	textHeight := len(strings.Split(l.text(0), "\n"))
@@ -59,9 +78,9 @@ func (l *LoginUser) getY() (yStart int, yEnd int) {
 
 // getX returns the initial start & end X
 func (l *LoginUser) getX() (xStart, xEnd int) {
-	xMax := 0
+	xMax := len("Filter: ") + len(l.query) + 10
 	for _, s := range l.list {
-		if len(s) > xMax {
+		if len(s)+10 > xMax {
 			xMax = len(s) + 10
 		}
 	}
@@ -111,7 +130,7 @@ func (l *LoginUser) registerKeyBind() error {
 	}
 
 	if err := l.g.SetKeybinding(l.viewName, gocui.KeyTab, gocui.ModNone, l.handleNav(func() {
-		if l.pos < len(l.list)-1 {
+		if l.pos < len(l.visible)-1 {
 			l.pos++
 		}
 	})); err != nil {
@@ -126,12 +145,28 @@ func (l *LoginUser) registerKeyBind() error {
 		return err
 	}
 	if err := l.g.SetKeybinding("", gocui.KeyArrowDown, gocui.ModNone, l.handleNav(func() {
-		if l.pos < len(l.list)-1 {
+		if l.pos < len(l.visible)-1 {
 			l.pos++
 		}
 	})); err != nil {
 		return err
 	}
+
+	if err := l.g.SetKeybinding(l.viewName, gocui.KeyBackspace, gocui.ModNone, l.handleBackspace); err != nil {
+		return err
+	}
+	if err := l.g.SetKeybinding(l.viewName, gocui.KeyBackspace2, gocui.ModNone, l.handleBackspace); err != nil {
+		return err
+	}
+	if err := l.g.SetKeybinding(l.viewName, gocui.KeyCtrlU, gocui.ModNone, l.handleClearQuery); err != nil {
+		return err
+	}
+
+	for ch := rune(' '); ch <= rune('~'); ch++ {
+		if err := l.g.SetKeybinding(l.viewName, ch, gocui.ModNone, l.handleType(ch)); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -143,6 +178,33 @@ func (l *LoginUser) handleNav(c func()) func(g *gocui.Gui, v *gocui.View) error
 	}
 }
 
+// handleType appends ch to the filter query and redraws the narrowed list.
+func (l *LoginUser) handleType(ch rune) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		l.query += string(ch)
+		l.recompute()
+		return l.write(v)
+	}
+}
+
+// handleBackspace removes the last rune of the filter query.
+func (l *LoginUser) handleBackspace(_ *gocui.Gui, v *gocui.View) error {
+	if l.query == "" {
+		return nil
+	}
+	runes := []rune(l.query)
+	l.query = string(runes[:len(runes)-1])
+	l.recompute()
+	return l.write(v)
+}
+
+// handleClearQuery clears the filter query, showing the full list again.
+func (l *LoginUser) handleClearQuery(_ *gocui.Gui, v *gocui.View) error {
+	l.query = ""
+	l.recompute()
+	return l.write(v)
+}
+
 // write writes the text into view
 func (l *LoginUser) write(v *gocui.View) error {
 	v.Clear()
@@ -156,15 +218,19 @@ func (l *LoginUser) text(pos int) string {
 	str.WriteString("\n")
 	str.WriteString("Select user to login")
 	str.WriteString("\n\n")
-	for i, s := range l.list {
+	for i, idx := range l.visible {
 		if i == pos {
-			str.WriteString(fmt.Sprintf("\u001B[33;1m▶ %s\u001B[0m\n", s))
+			str.WriteString(fmt.Sprintf("\u001B[33;1m▶ %s\u001B[0m\n", l.list[idx]))
 		} else {
-			str.WriteString(fmt.Sprintf("  %s\n", s))
+			str.WriteString(fmt.Sprintf("  %s\n", l.list[idx]))
 		}
 	}
+	if len(l.visible) == 0 {
+		str.WriteString("  (no match)\n")
+	}
 	str.WriteString("\n")
-	str.WriteString("Yes [\u001B[32;1mEnter\u001B[0m]   Cancel [\u001B[31;1mCTRL+C\u001B[0m]")
+	str.WriteString(fmt.Sprintf("Filter: %s\u2588\n", l.query))
+	str.WriteString("Yes [\u001B[32;1mEnter\u001B[0m]   Clear [\u001B[36;1mCTRL+U\u001B[0m]   Cancel [\u001B[31;1mCTRL+C\u001B[0m]")
 	return prependTab(str.String())
 }
 
@@ -175,10 +241,13 @@ func prependTab(text string) (res string) {
 	return
 }
 
-// handleEnter get the current list position then set to selected user
-// then exit the UI
+// handleEnter get the current visible position then set to selected user
+// then exit the UI. Enter on an empty filter result is a no-op.
 func (l *LoginUser) handleEnter(_ *gocui.Gui, _ *gocui.View) error {
-	l.selectedUser = l.list[l.pos]
+	if len(l.visible) == 0 {
+		return nil
+	}
+	l.selectedUser = l.list[l.visible[l.pos]]
 	return gocui.ErrQuit
 }
 
@@ -189,6 +258,9 @@ func (l *LoginUser) Run() (string, error) {
 	if err == gocui.ErrQuit {
 		return l.selectedUser, nil
 	}
+	if err != nil && l.logger != nil {
+		l.logger.Error("login user view exited with error", "error", err)
+	}
 	return l.selectedUser, err
 }
 